@@ -0,0 +1,31 @@
+package migrations
+
+func init() {
+	register(Migration{
+		Version:  "add_live_tuple_unique_index",
+		Replaces: "",
+
+		// Enforces at most one live row per tuple identity, so an ON
+		// CONFLICT DO NOTHING upsert can recognize an already-live tuple
+		// without a round-trip SELECT, and a double-CREATE can be rejected
+		// cleanly instead of failing with an unindexed unique violation.
+		UpSQL: `CREATE UNIQUE INDEX CONCURRENTLY uq_tuple_living ON tuple (
+    namespace,
+    object_id,
+    relation,
+    userset_namespace,
+    userset_object_id,
+    userset_relation
+) WHERE deleted_transaction = 9223372036854775807;`,
+
+		DownSQL: `DROP INDEX CONCURRENTLY uq_tuple_living;`,
+
+		// CONCURRENTLY avoids an ACCESS EXCLUSIVE lock on tuple for the
+		// index build, which would otherwise block every read and write on
+		// the hot path this migration exists to speed up. It cannot run
+		// inside a transaction block, so the runner must apply this
+		// migration's UpSQL/DownSQL outside its normal per-migration
+		// transaction wrapper.
+		NoTransaction: true,
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+// Migration describes a single schema change applied to a postgres
+// datastore, expressed as a forward/backward pair of plain SQL statements.
+type Migration struct {
+	// Version uniquely identifies this migration.
+	Version string
+	// Replaces is the Version of the migration this one applies on top of,
+	// or "" if this is the first migration.
+	Replaces string
+	// UpSQL runs the migration forward.
+	UpSQL string
+	// DownSQL reverses the migration.
+	DownSQL string
+	// NoTransaction marks a migration that must be applied outside of a
+	// BEGIN/COMMIT block, because PostgreSQL refuses to run some
+	// statements (e.g. CREATE/DROP INDEX CONCURRENTLY) inside a
+	// transaction. A migration runner must check this flag and skip its
+	// normal per-migration transaction wrapper when applying UpSQL/DownSQL
+	// for such a migration.
+	NoTransaction bool
+}
+
+// All is the ordered list of migrations known to this datastore, applied
+// from the zero schema in slice order.
+var All []Migration
+
+func register(m Migration) {
+	All = append(All, m)
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+)
+
+func findMigration(t *testing.T, version string) Migration {
+	t.Helper()
+	for _, m := range All {
+		if m.Version == version {
+			return m
+		}
+	}
+	t.Fatalf("migration %q is not registered in All", version)
+	return Migration{}
+}
+
+func TestConcurrentIndexMigrationIsMarkedNoTransaction(t *testing.T) {
+	m := findMigration(t, "add_live_tuple_unique_index")
+
+	if !strings.Contains(m.UpSQL, "CONCURRENTLY") || !strings.Contains(m.DownSQL, "CONCURRENTLY") {
+		t.Fatalf("migration %q no longer uses CONCURRENTLY in both UpSQL and DownSQL, so this test's premise drifted: up=%q down=%q", m.Version, m.UpSQL, m.DownSQL)
+	}
+
+	if !m.NoTransaction {
+		t.Fatalf("migration %q runs CREATE/DROP INDEX CONCURRENTLY, which PostgreSQL refuses inside a "+
+			"transaction block, but is not marked NoTransaction — a runner that wraps every migration "+
+			"in a transaction by default would fail to apply it", m.Version)
+	}
+}
@@ -2,9 +2,11 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/authzed/spicedb/internal/datastore"
 
@@ -17,6 +19,19 @@ const (
 	errUnableToVerifyRelation  = "unable to verify relation: %w"
 )
 
+// pgUniqueViolationCode is the PostgreSQL SQLSTATE for a unique constraint
+// violation. The COPY fast path has no ON CONFLICT equivalent, so a CREATE
+// for an already-live tuple surfaces as this error against uq_tuple_living
+// rather than being skipped by ON CONFLICT DO NOTHING as on the upsert path.
+const pgUniqueViolationCode = "23505"
+
+// bulkInsertChunkSize is the largest number of CREATE/TOUCH mutations a
+// single WriteTuples call writes with a squirrel multi-row INSERT; a call
+// carrying more than this switches to the COPY protocol. Squirrel binds one parameter per
+// column per row, so a sufficiently large batch will blow through
+// PostgreSQL's 65535 bind parameter limit; COPY has no such limit.
+const bulkInsertChunkSize = 100
+
 var (
 	writeTuple = psql.Insert(tableTuple).Columns(
 		colNamespace,
@@ -28,9 +43,31 @@ var (
 		colCreatedTxn,
 	)
 
+	// writeTupleUpsert backs the non-COPY write path. The ON CONFLICT target
+	// mirrors the uq_tuple_living partial unique index (see the
+	// add_live_tuple_unique_index migration): a CREATE/TOUCH that would
+	// recreate an already-live tuple becomes a no-op instead of writing a
+	// redundant row or failing with a unique violation.
+	writeTupleUpsert = writeTuple.Suffix(fmt.Sprintf(
+		"ON CONFLICT (%s, %s, %s, %s, %s, %s) WHERE %s = %d DO NOTHING",
+		colNamespace, colObjectID, colRelation, colUsersetNamespace, colUsersetObjectID, colUsersetRelation,
+		colDeletedTxn, liveDeletedTxnID,
+	))
+
 	deleteTuple = psql.Update(tableTuple).Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
 
 	queryTupleExists = psql.Select(colID).From(tableTuple)
+
+	queryTupleCreatedTxn = psql.Select(colCreatedTxn).From(tableTuple)
+
+	queryTuplesForPreconditions = psql.Select(
+		colNamespace,
+		colObjectID,
+		colRelation,
+		colUsersetNamespace,
+		colUsersetObjectID,
+		colUsersetRelation,
+	).From(tableTuple)
 )
 
 func (pgd *pgDatastore) WriteTuples(ctx context.Context, preconditions []*v0.RelationTuple, mutations []*v0.RelationTupleUpdate) (datastore.Revision, error) {
@@ -42,9 +79,17 @@ func (pgd *pgDatastore) WriteTuples(ctx context.Context, preconditions []*v0.Rel
 	}
 	defer tx.Rollback(ctx)
 
-	// Check the preconditions
-	for _, tpl := range preconditions {
-		sql, args, err := queryTupleExists.Where(exactTupleClause(tpl)).Limit(1).ToSql()
+	// Check the preconditions. The common case of a single precondition is
+	// kept as its own round-trip SELECT ... LIMIT 1; anything larger is
+	// folded into one query so N preconditions don't cost N round-trips.
+	// Both branches require a live row for the precondition's tuple
+	// identity: the N==1 query used to match any historical row for that
+	// identity, including deleted ones, which let a precondition on a
+	// deleted tuple pass incorrectly. That's fixed here uniformly across
+	// both branches, not just introduced for N>1.
+	if len(preconditions) == 1 {
+		tpl := preconditions[0]
+		sql, args, err := singlePreconditionQuery(tpl)
 		if err != nil {
 			return datastore.NoRevision, fmt.Errorf(errUnableToWriteTuples, err)
 		}
@@ -58,6 +103,10 @@ func (pgd *pgDatastore) WriteTuples(ctx context.Context, preconditions []*v0.Rel
 			}
 			return datastore.NoRevision, fmt.Errorf(errUnableToWriteTuples, err)
 		}
+	} else if len(preconditions) > 1 {
+		if err := checkPreconditions(ctx, tx, preconditions); err != nil {
+			return datastore.NoRevision, err
+		}
 	}
 
 	newTxnID, err := createNewTransaction(ctx, tx)
@@ -65,14 +114,28 @@ func (pgd *pgDatastore) WriteTuples(ctx context.Context, preconditions []*v0.Rel
 		return datastore.NoRevision, fmt.Errorf(errUnableToWriteTuples, err)
 	}
 
-	bulkWrite := writeTuple
+	var createCount int
+	for _, mutation := range mutations {
+		if mutation.Operation == v0.RelationTupleUpdate_TOUCH || mutation.Operation == v0.RelationTupleUpdate_CREATE {
+			createCount++
+		}
+	}
+	useCopyFastPath := createCount > bulkInsertChunkSize
+
+	// writeTupleUpsert's ON CONFLICT DO NOTHING already makes a TOUCH of a
+	// still-live tuple a no-op, so the pre-emptive delete below is only
+	// needed on the COPY fast path, where COPY cannot express an upsert and
+	// the old two-step delete-then-insert flow must still run.
+	bulkWrite := writeTupleUpsert
 	bulkWriteHasValues := false
+	bulkWriteValueCount := 0
 
 	// Process the actual updates
 	for _, mutation := range mutations {
 		tpl := mutation.Tuple
 
-		if mutation.Operation == v0.RelationTupleUpdate_TOUCH || mutation.Operation == v0.RelationTupleUpdate_DELETE {
+		if mutation.Operation == v0.RelationTupleUpdate_DELETE ||
+			(mutation.Operation == v0.RelationTupleUpdate_TOUCH && useCopyFastPath) {
 			sql, args, err := deleteTuple.Where(exactTupleClause(tpl)).Set(colDeletedTxn, newTxnID).ToSql()
 			if err != nil {
 				return datastore.NoRevision, fmt.Errorf(errUnableToWriteTuples, err)
@@ -83,7 +146,7 @@ func (pgd *pgDatastore) WriteTuples(ctx context.Context, preconditions []*v0.Rel
 			}
 		}
 
-		if mutation.Operation == v0.RelationTupleUpdate_TOUCH || mutation.Operation == v0.RelationTupleUpdate_CREATE {
+		if !useCopyFastPath && (mutation.Operation == v0.RelationTupleUpdate_TOUCH || mutation.Operation == v0.RelationTupleUpdate_CREATE) {
 			bulkWrite = bulkWrite.Values(
 				tpl.ObjectAndRelation.Namespace,
 				tpl.ObjectAndRelation.ObjectId,
@@ -94,19 +157,43 @@ func (pgd *pgDatastore) WriteTuples(ctx context.Context, preconditions []*v0.Rel
 				newTxnID,
 			)
 			bulkWriteHasValues = true
+			bulkWriteValueCount++
 		}
 	}
 
-	if bulkWriteHasValues {
+	if useCopyFastPath {
+		if _, err := tx.CopyFrom(
+			ctx,
+			pgx.Identifier{tableTuple},
+			[]string{
+				colNamespace,
+				colObjectID,
+				colRelation,
+				colUsersetNamespace,
+				colUsersetObjectID,
+				colUsersetRelation,
+				colCreatedTxn,
+			},
+			&tupleCopyFromSource{mutations: mutations, newTxnID: newTxnID},
+		); err != nil {
+			return datastore.NoRevision, translateCopyError(err)
+		}
+	} else if bulkWriteHasValues {
 		sql, args, err := bulkWrite.ToSql()
 		if err != nil {
 			return datastore.NoRevision, fmt.Errorf(errUnableToWriteTuples, err)
 		}
 
-		_, err = tx.Exec(ctx, sql, args...)
+		tag, err := tx.Exec(ctx, sql, args...)
 		if err != nil {
 			return datastore.NoRevision, fmt.Errorf(errUnableToWriteTuples, err)
 		}
+
+		if int(tag.RowsAffected()) < bulkWriteValueCount {
+			if err := checkForDuplicateCreates(ctx, tx, newTxnID, mutations); err != nil {
+				return datastore.NoRevision, err
+			}
+		}
 	}
 
 	err = tx.Commit(ctx)
@@ -117,6 +204,190 @@ func (pgd *pgDatastore) WriteTuples(ctx context.Context, preconditions []*v0.Rel
 	return revisionFromTransaction(newTxnID), nil
 }
 
+// translateCopyError maps the unique violation a duplicate CREATE raises
+// against uq_tuple_living on the COPY fast path to
+// datastore.ErrTupleAlreadyExists, matching the error the upsert path
+// returns for the same condition on smaller batches. Any other error is
+// wrapped the same way as the rest of WriteTuples.
+func translateCopyError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolationCode {
+		return datastore.ErrTupleAlreadyExists
+	}
+
+	return fmt.Errorf(errUnableToWriteTuples, err)
+}
+
+// checkPreconditions resolves every precondition tuple with a single query
+// that ORs together each tuple's exactTupleClause, then returns
+// datastore.NewPreconditionFailedErr for the first precondition (in the
+// caller's original order) that wasn't found among the live tuples.
+func checkPreconditions(ctx context.Context, tx pgx.Tx, preconditions []*v0.RelationTuple) error {
+	orClause := make(sq.Or, 0, len(preconditions))
+	for _, tpl := range preconditions {
+		orClause = append(orClause, exactTupleClause(tpl))
+	}
+
+	sql, args, err := queryTuplesForPreconditions.
+		Where(sq.Eq{colDeletedTxn: liveDeletedTxnID}).
+		Where(orClause).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf(errUnableToWriteTuples, err)
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf(errUnableToWriteTuples, err)
+	}
+	defer rows.Close()
+
+	found := make(map[tupleIdentity]struct{}, len(preconditions))
+	for rows.Next() {
+		var identity tupleIdentity
+		if err := rows.Scan(
+			&identity.namespace,
+			&identity.objectID,
+			&identity.relation,
+			&identity.usersetNamespace,
+			&identity.usersetObjectID,
+			&identity.usersetRelation,
+		); err != nil {
+			return fmt.Errorf(errUnableToWriteTuples, err)
+		}
+		found[identity] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf(errUnableToWriteTuples, err)
+	}
+
+	if missing := firstMissingPrecondition(preconditions, found); missing != nil {
+		return datastore.NewPreconditionFailedErr(missing)
+	}
+
+	return nil
+}
+
+// tupleIdentity is the comparable tuple (ns, obj, rel, uns, uobj, urel) key
+// used to diff the rows returned by checkPreconditions against the
+// requested preconditions.
+type tupleIdentity struct {
+	namespace        string
+	objectID         string
+	relation         string
+	usersetNamespace string
+	usersetObjectID  string
+	usersetRelation  string
+}
+
+func tupleIdentityOf(tpl *v0.RelationTuple) tupleIdentity {
+	return tupleIdentity{
+		namespace:        tpl.ObjectAndRelation.Namespace,
+		objectID:         tpl.ObjectAndRelation.ObjectId,
+		relation:         tpl.ObjectAndRelation.Relation,
+		usersetNamespace: tpl.User.GetUserset().Namespace,
+		usersetObjectID:  tpl.User.GetUserset().ObjectId,
+		usersetRelation:  tpl.User.GetUserset().Relation,
+	}
+}
+
+// firstMissingPrecondition walks preconditions in their original order and
+// returns the first one whose identity isn't in found, preserving the
+// stable-ordering guarantee callers depend on. Returns nil if every
+// precondition was found.
+func firstMissingPrecondition(preconditions []*v0.RelationTuple, found map[tupleIdentity]struct{}) *v0.RelationTuple {
+	for _, tpl := range preconditions {
+		if _, ok := found[tupleIdentityOf(tpl)]; !ok {
+			return tpl
+		}
+	}
+
+	return nil
+}
+
+// checkForDuplicateCreates is invoked when the ON CONFLICT DO NOTHING upsert
+// wrote fewer rows than were requested. A TOUCH is expected to no-op against
+// an already-live tuple, but a CREATE is not, so this walks the CREATE
+// mutations and returns datastore.ErrTupleAlreadyExists for the first one
+// whose live row predates this transaction.
+func checkForDuplicateCreates(ctx context.Context, tx pgx.Tx, newTxnID uint64, mutations []*v0.RelationTupleUpdate) error {
+	for _, mutation := range mutations {
+		if mutation.Operation != v0.RelationTupleUpdate_CREATE {
+			continue
+		}
+
+		sql, args, err := queryTupleCreatedTxn.
+			Where(exactTupleClause(mutation.Tuple)).
+			Where(sq.Eq{colDeletedTxn: liveDeletedTxnID}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteTuples, err)
+		}
+
+		var createdTxn uint64
+		if err := tx.QueryRow(ctx, sql, args...).Scan(&createdTxn); err != nil {
+			return fmt.Errorf(errUnableToWriteTuples, err)
+		}
+
+		if createdTxn != newTxnID {
+			return datastore.ErrTupleAlreadyExists
+		}
+	}
+
+	return nil
+}
+
+// tupleCopyFromSource adapts the CREATE/TOUCH mutations of a WriteTuples
+// call into a pgx.CopyFromSource so they can be streamed into tableTuple
+// via the COPY protocol instead of a squirrel multi-row INSERT.
+type tupleCopyFromSource struct {
+	mutations []*v0.RelationTupleUpdate
+	newTxnID  uint64
+	index     int
+}
+
+func (s *tupleCopyFromSource) Next() bool {
+	for s.index < len(s.mutations) {
+		op := s.mutations[s.index].Operation
+		if op == v0.RelationTupleUpdate_TOUCH || op == v0.RelationTupleUpdate_CREATE {
+			return true
+		}
+		s.index++
+	}
+	return false
+}
+
+func (s *tupleCopyFromSource) Values() ([]interface{}, error) {
+	tpl := s.mutations[s.index].Tuple
+	s.index++
+
+	return []interface{}{
+		tpl.ObjectAndRelation.Namespace,
+		tpl.ObjectAndRelation.ObjectId,
+		tpl.ObjectAndRelation.Relation,
+		tpl.User.GetUserset().Namespace,
+		tpl.User.GetUserset().ObjectId,
+		tpl.User.GetUserset().Relation,
+		s.newTxnID,
+	}, nil
+}
+
+func (s *tupleCopyFromSource) Err() error {
+	return nil
+}
+
+// singlePreconditionQuery builds the SELECT ... LIMIT 1 used for the N==1
+// precondition hot path. It requires a live row for the exact tuple
+// identity, matching the liveness requirement checkPreconditions applies
+// for N>1.
+func singlePreconditionQuery(tpl *v0.RelationTuple) (string, []interface{}, error) {
+	return queryTupleExists.
+		Where(exactTupleClause(tpl)).
+		Where(sq.Eq{colDeletedTxn: liveDeletedTxnID}).
+		Limit(1).
+		ToSql()
+}
+
 func exactTupleClause(tpl *v0.RelationTuple) sq.Eq {
 	return sq.Eq{
 		colNamespace:        tpl.ObjectAndRelation.Namespace,
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/authzed/spicedb/internal/datastore"
+
+	v0 "github.com/authzed/spicedb/pkg/proto/authzed/api/v0"
+)
+
+func newTestTuple(namespace, objectID, relation, usersetNamespace, usersetObjectID, usersetRelation string) *v0.RelationTuple {
+	return &v0.RelationTuple{
+		ObjectAndRelation: &v0.ObjectAndRelation{
+			Namespace: namespace,
+			ObjectId:  objectID,
+			Relation:  relation,
+		},
+		User: &v0.User{
+			UserOneof: &v0.User_Userset{
+				Userset: &v0.ObjectAndRelation{
+					Namespace: usersetNamespace,
+					ObjectId:  usersetObjectID,
+					Relation:  usersetRelation,
+				},
+			},
+		},
+	}
+}
+
+func newTestMutation(op v0.RelationTupleUpdate_Operation, namespace, objectID, relation, usersetNamespace, usersetObjectID, usersetRelation string) *v0.RelationTupleUpdate {
+	return &v0.RelationTupleUpdate{
+		Operation: op,
+		Tuple:     newTestTuple(namespace, objectID, relation, usersetNamespace, usersetObjectID, usersetRelation),
+	}
+}
+
+func TestTupleCopyFromSourceSkipsDeletesAndStampsTxnID(t *testing.T) {
+	mutations := []*v0.RelationTupleUpdate{
+		newTestMutation(v0.RelationTupleUpdate_DELETE, "ns", "obj1", "rel", "uns", "uobj1", "urel"),
+		newTestMutation(v0.RelationTupleUpdate_CREATE, "ns", "obj2", "rel", "uns", "uobj2", "urel"),
+		newTestMutation(v0.RelationTupleUpdate_TOUCH, "ns", "obj3", "rel", "uns", "uobj3", "urel"),
+	}
+
+	source := &tupleCopyFromSource{mutations: mutations, newTxnID: 42}
+
+	var rows [][]interface{}
+	for source.Next() {
+		values, err := source.Values()
+		if err != nil {
+			t.Fatalf("unexpected error from Values(): %v", err)
+		}
+		rows = append(rows, values)
+	}
+	if err := source.Err(); err != nil {
+		t.Fatalf("unexpected error from Err(): %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows (CREATE and TOUCH only, DELETE skipped), got %d", len(rows))
+	}
+
+	expectedCreate := []interface{}{"ns", "obj2", "rel", "uns", "uobj2", "urel", uint64(42)}
+	if !reflect.DeepEqual(rows[0], expectedCreate) {
+		t.Errorf("row 0 = %v, want %v", rows[0], expectedCreate)
+	}
+
+	expectedTouch := []interface{}{"ns", "obj3", "rel", "uns", "uobj3", "urel", uint64(42)}
+	if !reflect.DeepEqual(rows[1], expectedTouch) {
+		t.Errorf("row 1 = %v, want %v", rows[1], expectedTouch)
+	}
+}
+
+func TestWriteTupleUpsertUsesOnConflictDoNothing(t *testing.T) {
+	sql, _, err := writeTupleUpsert.Values("ns", "obj", "rel", "uns", "uobj", "urel", uint64(1)).ToSql()
+	if err != nil {
+		t.Fatalf("unexpected error building SQL: %v", err)
+	}
+
+	if !strings.Contains(sql, "ON CONFLICT") || !strings.Contains(sql, "DO NOTHING") {
+		t.Errorf("writeTupleUpsert SQL missing ON CONFLICT ... DO NOTHING, so a TOUCH of an "+
+			"already-live tuple would no longer no-op: %s", sql)
+	}
+}
+
+func TestTranslateCopyErrorMapsUniqueViolation(t *testing.T) {
+	// Simulates the error CopyFrom returns for a CREATE (over the COPY
+	// threshold) that collides with the uq_tuple_living partial index.
+	pgErr := &pgconn.PgError{Code: pgUniqueViolationCode}
+	wrapped := fmt.Errorf("copy from tuple failed: %w", pgErr)
+
+	got := translateCopyError(wrapped)
+	if !errors.Is(got, datastore.ErrTupleAlreadyExists) {
+		t.Errorf("translateCopyError(%v) = %v, want datastore.ErrTupleAlreadyExists", wrapped, got)
+	}
+}
+
+func TestTranslateCopyErrorWrapsOtherErrors(t *testing.T) {
+	other := errors.New("connection reset by peer")
+
+	got := translateCopyError(other)
+	if errors.Is(got, datastore.ErrTupleAlreadyExists) {
+		t.Fatalf("translateCopyError(%v) incorrectly mapped a non-unique-violation error to ErrTupleAlreadyExists", other)
+	}
+	if !errors.Is(got, other) {
+		t.Errorf("translateCopyError(%v) = %v, want it to still wrap the original error", other, got)
+	}
+}
+
+func TestSinglePreconditionQueryRequiresLiveTuple(t *testing.T) {
+	tpl := newTestTuple("ns", "obj", "rel", "uns", "uobj", "urel")
+
+	sql, args, err := singlePreconditionQuery(tpl)
+	if err != nil {
+		t.Fatalf("unexpected error building SQL: %v", err)
+	}
+
+	if !strings.Contains(sql, colDeletedTxn) {
+		t.Fatalf("singlePreconditionQuery no longer filters on %s, so a precondition on a deleted "+
+			"tuple would incorrectly pass: %s", colDeletedTxn, sql)
+	}
+
+	var foundLiveArg bool
+	for _, arg := range args {
+		if arg == liveDeletedTxnID {
+			foundLiveArg = true
+		}
+	}
+	if !foundLiveArg {
+		t.Fatalf("singlePreconditionQuery args %v missing liveDeletedTxnID bind value", args)
+	}
+}
+
+func TestFirstMissingPreconditionPicksFirstInCallerOrder(t *testing.T) {
+	present := newTestTuple("ns", "obj1", "rel", "uns", "uobj1", "urel")
+	missingFirst := newTestTuple("ns", "obj2", "rel", "uns", "uobj2", "urel")
+	missingSecond := newTestTuple("ns", "obj3", "rel", "uns", "uobj3", "urel")
+
+	preconditions := []*v0.RelationTuple{present, missingFirst, missingSecond}
+	found := map[tupleIdentity]struct{}{
+		tupleIdentityOf(present): {},
+	}
+
+	got := firstMissingPrecondition(preconditions, found)
+	if got != missingFirst {
+		t.Fatalf("firstMissingPrecondition() = %v, want the first missing precondition (%v) in caller order, not the second (%v)",
+			got, missingFirst, missingSecond)
+	}
+}
+
+func TestFirstMissingPreconditionNilWhenAllFound(t *testing.T) {
+	a := newTestTuple("ns", "obj1", "rel", "uns", "uobj1", "urel")
+	b := newTestTuple("ns", "obj2", "rel", "uns", "uobj2", "urel")
+
+	found := map[tupleIdentity]struct{}{
+		tupleIdentityOf(a): {},
+		tupleIdentityOf(b): {},
+	}
+
+	if got := firstMissingPrecondition([]*v0.RelationTuple{a, b}, found); got != nil {
+		t.Fatalf("firstMissingPrecondition() = %v, want nil when every precondition is present", got)
+	}
+}